@@ -0,0 +1,380 @@
+// SPDX-FileCopyrightText: 2022-2024 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by Pool methods when the pool has already been
+// closed via Close.
+var ErrPoolClosed = errors.New("mail: connection pool is closed")
+
+// ErrPoolTimeout is returned by GetWithContext and GetWithTimeout when the
+// deadline elapses before a connection becomes available.
+var ErrPoolTimeout = errors.New("mail: timed out waiting for a pool connection")
+
+// DialFunc is the signature used by the connPool to establish new network
+// connections. It matches net.Dialer.DialContext so that a net.Dialer (or
+// any compatible type) can be passed directly to NewConnPool.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Pool describes a pool of reusable net.Conn connections that can be handed
+// out to and returned by concurrent callers.
+type Pool interface {
+	// Get returns an idle connection from the pool or dials a new one if
+	// the pool has not yet reached its maximum capacity.
+	Get() (net.Conn, error)
+
+	// GetWithContext behaves like Get, but returns ErrPoolTimeout if ctx is
+	// cancelled or its deadline elapses before a connection becomes
+	// available.
+	GetWithContext(ctx context.Context) (net.Conn, error)
+
+	// GetWithTimeout behaves like Get, but returns ErrPoolTimeout if d
+	// elapses before a connection becomes available.
+	GetWithTimeout(d time.Duration) (net.Conn, error)
+
+	// Close closes the pool along with all idle connections it currently
+	// holds. Connections that are checked out at the time Close is called
+	// are closed as soon as they are returned.
+	Close() error
+
+	// Size returns the number of idle connections currently held by the
+	// pool.
+	Size() int
+
+	// Stat returns a point-in-time snapshot of the pool's statistics.
+	Stat() PoolStat
+
+	// SetMetricsCollector plugs a MetricsCollector into the pool. It is
+	// invoked synchronously from pool operations, so implementations must
+	// not block.
+	SetMetricsCollector(m MetricsCollector)
+
+	// EnableWaitSampler starts a background goroutine that buckets the
+	// wait durations recorded whenever Get blocks because the pool is at
+	// capacity. It is opt-in and a no-op if called more than once.
+	EnableWaitSampler(buckets []time.Duration)
+
+	// WaitHistogram returns a snapshot of the wait-time histogram
+	// recorded since EnableWaitSampler was called, or nil if the sampler
+	// was never enabled.
+	WaitHistogram() []int64
+
+	// Ping forces a dial probe against the pool's configured address,
+	// bypassing both the backoff cooldown and an open circuit breaker. A
+	// successful probe closes the circuit breaker, however it was tripped.
+	Ping(ctx context.Context) error
+
+	// Reset manually closes the pool's circuit breaker, clearing the last
+	// dial error and consecutive failure count so Get resumes dialing
+	// immediately instead of waiting out the backoff or refusing outright.
+	Reset()
+}
+
+// connPool is the default Pool implementation. It hands out connections
+// wrapped in a PoolConn, which return themselves to the idle channel on
+// Close instead of tearing down the underlying net.Conn.
+type connPool struct {
+	mu      sync.Mutex
+	conns   chan net.Conn
+	dial    DialFunc
+	network string
+	address string
+	current int
+	max     int
+	closed  bool
+
+	afterConnect func(net.Conn) error
+	beforeClose  func(net.Conn)
+
+	metrics MetricsCollector
+	sampler *waitSampler
+
+	created      int64
+	closedConns  int64
+	failed       int64
+	waitCount    int64
+	waitDuration time.Duration
+
+	maxConsecutiveFailures int
+	consecutiveFailures    int
+	lastBuildErr           error
+	lastBuildErrAt         time.Time
+	open                   bool
+}
+
+// PoolConn wraps a net.Conn handed out by a connPool. Closing a PoolConn
+// returns the underlying connection to the pool instead of closing it,
+// unless the connection has been marked unusable.
+type PoolConn struct {
+	net.Conn
+	pool     *connPool
+	unusable bool
+}
+
+// NewConnPool dials address over network using dial and pre-fills the
+// returned Pool with min idle connections. The pool never holds more than
+// max connections at a time; once max is reached, Get blocks until a
+// connection is returned. It is a convenience wrapper around
+// NewConnPoolWithConfig for callers that don't need AfterConnect/BeforeClose
+// hooks.
+func NewConnPool(ctx context.Context, min, max int, dial DialFunc, network, address string) (Pool, error) {
+	return NewConnPoolWithConfig(ctx, ConnPoolConfig{
+		Min:     min,
+		Max:     max,
+		Dial:    dial,
+		Network: network,
+		Address: address,
+	})
+}
+
+// dialConn dials a new connection and, if configured, runs AfterConnect on
+// it. If AfterConnect returns an error, the freshly dialed connection is
+// closed and the error is surfaced to the caller.
+func (c *connPool) dialConn(ctx context.Context) (net.Conn, error) {
+	conn, err := c.dial(ctx, c.network, c.address)
+	if err != nil {
+		return nil, err
+	}
+	if c.afterConnect != nil {
+		if err = c.afterConnect(conn); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("mail: AfterConnect hook failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// Get returns an idle connection from the pool if one is available,
+// otherwise dials a new one as long as the pool has not yet reached its
+// maximum capacity. If the pool is already at capacity, Get blocks until a
+// connection is returned to it.
+func (c *connPool) Get() (net.Conn, error) {
+	return c.get(nil, nil)
+}
+
+// GetWithContext behaves like Get, but returns ErrPoolTimeout as soon as ctx
+// is cancelled or its deadline elapses while waiting for a connection to be
+// returned to an already full pool.
+func (c *connPool) GetWithContext(ctx context.Context) (net.Conn, error) {
+	return c.get(ctx.Done(), nil)
+}
+
+// GetWithTimeout behaves like Get, but returns ErrPoolTimeout if d elapses
+// while waiting for a connection to be returned to an already full pool.
+func (c *connPool) GetWithTimeout(d time.Duration) (net.Conn, error) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	return c.get(nil, timer.C)
+}
+
+// get is the shared implementation behind Get, GetWithContext and
+// GetWithTimeout. A nil done or timeout channel simply disables that
+// cancellation path, reproducing Get's indefinite block.
+func (c *connPool) get(done <-chan struct{}, timeout <-chan time.Time) (net.Conn, error) {
+	select {
+	case conn, ok := <-c.conns:
+		if !ok {
+			return nil, ErrPoolClosed
+		}
+		return c.wrap(conn), nil
+	default:
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	if c.current >= c.max {
+		c.mu.Unlock()
+		waitStart := time.Now()
+		select {
+		case conn, ok := <-c.conns:
+			if !ok {
+				return nil, ErrPoolClosed
+			}
+			c.recordWait(time.Since(waitStart))
+			return c.wrap(conn), nil
+		case <-done:
+			return nil, ErrPoolTimeout
+		case <-timeout:
+			return nil, ErrPoolTimeout
+		}
+	}
+	if err := c.breakerErrLocked(); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.current++
+	c.mu.Unlock()
+
+	conn, err := c.dialConn(context.Background())
+	if err != nil {
+		c.mu.Lock()
+		c.current--
+		c.failed++
+		c.mu.Unlock()
+		c.recordDialFailure(err)
+		if c.metrics != nil {
+			c.metrics.ConnFailed()
+		}
+		return nil, fmt.Errorf("mail: failed to dial new pool connection: %w", err)
+	}
+	c.mu.Lock()
+	c.created++
+	c.mu.Unlock()
+	c.recordDialSuccess()
+	if c.metrics != nil {
+		c.metrics.ConnCreated()
+	}
+	return c.wrap(conn), nil
+}
+
+// recordWait updates the pool's wait statistics and, if configured, feeds
+// the MetricsCollector and the background wait-time sampler.
+func (c *connPool) recordWait(d time.Duration) {
+	c.mu.Lock()
+	c.waitCount++
+	c.waitDuration += d
+	sampler := c.sampler
+	c.mu.Unlock()
+
+	if c.metrics != nil {
+		c.metrics.Wait(d)
+	}
+	if sampler != nil {
+		sampler.record(d)
+	}
+}
+
+// Close closes the pool. Idle connections are closed immediately, while
+// connections that are currently checked out are closed as soon as they are
+// returned via PoolConn.Close.
+func (c *connPool) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.conns)
+	sampler := c.sampler
+	c.mu.Unlock()
+
+	if sampler != nil {
+		close(sampler.samples)
+	}
+
+	for conn := range c.conns {
+		if c.beforeClose != nil {
+			c.beforeClose(conn)
+		}
+		_ = conn.Close()
+		c.mu.Lock()
+		c.current--
+		c.closedConns++
+		c.mu.Unlock()
+		if c.metrics != nil {
+			c.metrics.ConnClosed()
+		}
+	}
+	return nil
+}
+
+// Size returns the number of idle connections currently held by the pool.
+func (c *connPool) Size() int {
+	return len(c.conns)
+}
+
+// Stat returns a point-in-time snapshot of the pool's statistics, primarily
+// intended for tuning min/max pool sizes in production.
+func (c *connPool) Stat() PoolStat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	idle := len(c.conns)
+	return PoolStat{
+		Total:        c.current,
+		Idle:         idle,
+		InUse:        c.current - idle,
+		Created:      c.created,
+		Closed:       c.closedConns,
+		Failed:       c.failed,
+		WaitCount:    c.waitCount,
+		WaitDuration: c.waitDuration,
+	}
+}
+
+// SetMetricsCollector plugs a MetricsCollector into the pool. It is invoked
+// synchronously from pool operations, so implementations must not block.
+func (c *connPool) SetMetricsCollector(m MetricsCollector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metrics = m
+}
+
+func (c *connPool) wrap(conn net.Conn) *PoolConn {
+	return &PoolConn{Conn: conn, pool: c}
+}
+
+// put returns conn to the pool, closing it instead if the pool is closed,
+// already full, or conn has been marked unusable.
+func (c *connPool) put(conn net.Conn, unusable bool) error {
+	if unusable {
+		return c.closeConn(conn)
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return c.closeConn(conn)
+	}
+	c.mu.Unlock()
+
+	select {
+	case c.conns <- conn:
+		return nil
+	default:
+		return c.closeConn(conn)
+	}
+}
+
+// closeConn runs BeforeClose (if configured), closes conn and records it in
+// the pool's statistics.
+func (c *connPool) closeConn(conn net.Conn) error {
+	if c.beforeClose != nil {
+		c.beforeClose(conn)
+	}
+	err := conn.Close()
+	c.mu.Lock()
+	c.current--
+	c.closedConns++
+	c.mu.Unlock()
+	if c.metrics != nil {
+		c.metrics.ConnClosed()
+	}
+	return err
+}
+
+// Close returns the wrapped connection to its pool, unless it has been
+// marked unusable via MarkUnusable, in which case the underlying net.Conn is
+// closed for good.
+func (p *PoolConn) Close() error {
+	return p.pool.put(p.Conn, p.unusable)
+}
+
+// MarkUnusable marks the connection as no longer fit for reuse, so that the
+// next call to Close closes the underlying net.Conn instead of returning it
+// to the pool.
+func (p *PoolConn) MarkUnusable() {
+	p.unusable = true
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2022-2024 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// minDialBackoff is the backoff applied after the first consecutive
+	// dial failure.
+	minDialBackoff = 100 * time.Millisecond
+	// maxDialBackoff caps the exponential backoff applied between dial
+	// attempts after repeated failures.
+	maxDialBackoff = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned by Get once the pool's circuit breaker has
+// tripped after MaxConsecutiveFailures consecutive dial failures. Call
+// Reset or Ping to close the breaker again.
+var ErrCircuitOpen = errors.New("mail: connection pool circuit breaker is open")
+
+// breakerErrLocked returns a non-nil error if a fresh dial should be
+// skipped: either because the circuit breaker has tripped open, or because
+// the last dial failure is still within its backoff window. c.mu must
+// already be held by the caller.
+func (c *connPool) breakerErrLocked() error {
+	if c.open {
+		return fmt.Errorf("mail: %w: last dial error: %s", ErrCircuitOpen, c.lastBuildErr)
+	}
+	if c.lastBuildErr == nil {
+		return nil
+	}
+	backoff := dialBackoff(c.consecutiveFailures)
+	if time.Since(c.lastBuildErrAt) < backoff {
+		return fmt.Errorf("mail: dial on cooldown after previous failure: %w", c.lastBuildErr)
+	}
+	return nil
+}
+
+// dialBackoff returns the exponential backoff (with jitter) to apply after
+// failures consecutive dial failures, capped at maxDialBackoff.
+func dialBackoff(failures int) time.Duration {
+	backoff := minDialBackoff
+	for i := 1; i < failures && backoff < maxDialBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxDialBackoff {
+		backoff = maxDialBackoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// recordDialFailure updates the breaker state after a failed dial, tripping
+// it open once MaxConsecutiveFailures consecutive failures are reached.
+func (c *connPool) recordDialFailure(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastBuildErr = err
+	c.lastBuildErrAt = time.Now()
+	c.consecutiveFailures++
+	if c.maxConsecutiveFailures > 0 && c.consecutiveFailures >= c.maxConsecutiveFailures {
+		c.open = true
+	}
+}
+
+// recordDialSuccess closes the breaker and clears the failure state after a
+// successful dial.
+func (c *connPool) recordDialSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.open = false
+	c.consecutiveFailures = 0
+	c.lastBuildErr = nil
+}
+
+// Ping forces a dial probe against the pool's configured address, bypassing
+// both the backoff cooldown and an open circuit breaker. A successful probe
+// closes the breaker, regardless of whether it was open; a failed one
+// counts towards MaxConsecutiveFailures like any other dial failure. The
+// probed connection itself is discarded.
+func (c *connPool) Ping(ctx context.Context) error {
+	conn, err := c.dialConn(ctx)
+	if err != nil {
+		c.mu.Lock()
+		c.failed++
+		c.mu.Unlock()
+		c.recordDialFailure(err)
+		if c.metrics != nil {
+			c.metrics.ConnFailed()
+		}
+		return fmt.Errorf("mail: probe dial failed: %w", err)
+	}
+	c.mu.Lock()
+	c.created++
+	c.current++
+	c.mu.Unlock()
+	c.recordDialSuccess()
+	if c.metrics != nil {
+		c.metrics.ConnCreated()
+	}
+	return c.closeConn(conn)
+}
+
+// Reset manually closes the pool's circuit breaker, clearing the last dial
+// error and consecutive failure count so Get resumes dialing immediately
+// instead of waiting out the backoff or refusing outright.
+func (c *connPool) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.open = false
+	c.consecutiveFailures = 0
+	c.lastBuildErr = nil
+}
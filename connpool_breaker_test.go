@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2022-2024 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDialBackoff(t *testing.T) {
+	if b := dialBackoff(1); b > minDialBackoff {
+		t.Errorf("expected backoff for first failure to be within minDialBackoff jitter, got %s", b)
+	}
+	if b := dialBackoff(100); b > maxDialBackoff {
+		t.Errorf("expected backoff to be capped at %s, got %s", maxDialBackoff, b)
+	}
+}
+
+func TestConnPool_CircuitBreakerTripsAfterMaxFailures(t *testing.T) {
+	var dials int32
+	failDial := func(_ context.Context, _, _ string) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		return nil, errors.New("simulated dial failure")
+	}
+
+	pool, err := NewConnPoolWithConfig(context.Background(), ConnPoolConfig{
+		Min:                    0,
+		Max:                    1,
+		Dial:                   failDial,
+		Network:                "tcp",
+		Address:                "127.0.0.1:0",
+		MaxConsecutiveFailures: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create connection pool: %s", err)
+	}
+	defer pool.Close()
+
+	cp, ok := pool.(*connPool)
+	if !ok {
+		t.Fatalf("expected *connPool, got %T", pool)
+	}
+
+	// Drive the breaker with two consecutive failures directly instead of
+	// via Get, since Get's own backoff would otherwise skip the second
+	// dial attempt before the cooldown from the first one elapses.
+	cp.recordDialFailure(errors.New("simulated dial failure"))
+	cp.recordDialFailure(errors.New("simulated dial failure"))
+
+	if _, err = pool.Get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen once the breaker trips, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&dials); got != 0 {
+		t.Errorf("expected no dial attempts while the breaker is open, got %d", got)
+	}
+
+	pool.Reset()
+	if _, err = pool.Get(); err == nil {
+		t.Error("expected Get to dial again and fail after Reset")
+	}
+	if got := atomic.LoadInt32(&dials); got != 1 {
+		t.Errorf("expected a dial attempt after Reset, got %d", got)
+	}
+}
+
+func TestConnPool_Ping(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 18
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	pool, err := newConnPool(serverPort)
+	if err != nil {
+		t.Fatalf("failed to create connection pool: %s", err)
+	}
+	defer pool.Close()
+
+	if err = pool.Ping(context.Background()); err != nil {
+		t.Errorf("expected Ping to succeed, got: %s", err)
+	}
+}
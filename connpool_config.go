@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2022-2024 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ConnPoolConfig configures a connPool created via NewConnPoolWithConfig.
+type ConnPoolConfig struct {
+	// Min is the number of connections the pool is pre-filled with.
+	Min int
+	// Max is the maximum number of connections the pool will ever hold.
+	Max int
+	// Dial establishes new connections to Network/Address.
+	Dial DialFunc
+	// Network and Address are passed to Dial as-is, e.g. "tcp" and
+	// "mail.example.com:25".
+	Network, Address string
+
+	// AfterConnect, if set, runs once for every connection the pool
+	// dials, before it is handed to a caller or placed in the idle
+	// channel. This is the place to perform a one-time STARTTLS
+	// negotiation, SMTP AUTH, or a custom EHLO greeting. If it returns an
+	// error, the dial is considered to have failed and the connection is
+	// closed.
+	AfterConnect func(net.Conn) error
+	// BeforeClose, if set, runs once for every connection the pool
+	// discards, right before it is closed. This is the place to send a
+	// clean QUIT.
+	BeforeClose func(net.Conn)
+
+	// MaxConsecutiveFailures is the number of consecutive dial failures
+	// after which the pool trips its circuit breaker open and refuses
+	// Get until a manual Reset() or a successful Ping(). Zero disables
+	// the breaker; dial failures then only incur the usual exponential
+	// backoff between attempts.
+	MaxConsecutiveFailures int
+}
+
+// NewConnPoolWithConfig dials cfg.Address over cfg.Network using cfg.Dial
+// and pre-fills the returned Pool with cfg.Min idle connections. The pool
+// never holds more than cfg.Max connections at a time; once cfg.Max is
+// reached, Get blocks until a connection is returned. Unlike NewConnPool,
+// it accepts AfterConnect/BeforeClose lifecycle hooks.
+func NewConnPoolWithConfig(ctx context.Context, cfg ConnPoolConfig) (Pool, error) {
+	if cfg.Dial == nil {
+		return nil, errors.New("mail: dial function must not be nil")
+	}
+	if cfg.Min < 0 || cfg.Max <= 0 || cfg.Min > cfg.Max {
+		return nil, fmt.Errorf("mail: invalid connection pool capacity (min: %d, max: %d)", cfg.Min, cfg.Max)
+	}
+
+	pool := &connPool{
+		conns:                  make(chan net.Conn, cfg.Max),
+		dial:                   cfg.Dial,
+		network:                cfg.Network,
+		address:                cfg.Address,
+		max:                    cfg.Max,
+		afterConnect:           cfg.AfterConnect,
+		beforeClose:            cfg.BeforeClose,
+		maxConsecutiveFailures: cfg.MaxConsecutiveFailures,
+	}
+	for i := 0; i < cfg.Min; i++ {
+		conn, err := pool.dialConn(ctx)
+		if err != nil {
+			pool.failed++
+			_ = pool.Close()
+			return nil, fmt.Errorf("mail: failed to pre-fill connection pool: %w", err)
+		}
+		pool.conns <- conn
+		pool.current++
+		pool.created++
+	}
+	return pool, nil
+}
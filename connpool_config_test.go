@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2022-2024 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewConnPoolWithConfig_AfterConnectBeforeClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 16
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	var mu sync.Mutex
+	var afterConnectCalls, beforeCloseCalls int
+
+	netDialer := net.Dialer{}
+	pool, err := NewConnPoolWithConfig(ctx, ConnPoolConfig{
+		Min:     2,
+		Max:     2,
+		Dial:    netDialer.DialContext,
+		Network: "tcp",
+		Address: fmt.Sprintf("127.0.0.1:%d", serverPort),
+		AfterConnect: func(net.Conn) error {
+			mu.Lock()
+			afterConnectCalls++
+			mu.Unlock()
+			return nil
+		},
+		BeforeClose: func(net.Conn) {
+			mu.Lock()
+			beforeCloseCalls++
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create connection pool: %s", err)
+	}
+
+	mu.Lock()
+	if afterConnectCalls != 2 {
+		t.Errorf("expected 2 AfterConnect calls, got %d", afterConnectCalls)
+	}
+	mu.Unlock()
+
+	if err = pool.Close(); err != nil {
+		t.Errorf("failed to close pool: %s", err)
+	}
+
+	mu.Lock()
+	if beforeCloseCalls != 2 {
+		t.Errorf("expected 2 BeforeClose calls, got %d", beforeCloseCalls)
+	}
+	mu.Unlock()
+}
+
+func TestNewConnPoolWithConfig_AfterConnectError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 17
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	wantErr := errors.New("simulated handshake failure")
+	netDialer := net.Dialer{}
+	_, err := NewConnPoolWithConfig(ctx, ConnPoolConfig{
+		Min:     1,
+		Max:     1,
+		Dial:    netDialer.DialContext,
+		Network: "tcp",
+		Address: fmt.Sprintf("127.0.0.1:%d", serverPort),
+		AfterConnect: func(net.Conn) error {
+			return wantErr
+		},
+	})
+	if err == nil {
+		t.Fatal("expected pool creation to fail when AfterConnect errors, got nil")
+	}
+	if !errors.Is(err, wantErr) {
+		t.Errorf("expected error to wrap %v, got %v", wantErr, err)
+	}
+}
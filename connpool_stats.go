@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2022-2024 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"sync"
+	"time"
+)
+
+// PoolStat is a point-in-time snapshot of a connPool's statistics, returned
+// by Pool.Stat(). It is the primary tool for tuning a pool's min/max sizes
+// in production, where Size() alone only shows the idle count.
+type PoolStat struct {
+	// Total is the number of connections currently held by the pool,
+	// idle or checked out.
+	Total int
+	// Idle is the number of connections currently idle in the pool.
+	Idle int
+	// InUse is the number of connections currently checked out.
+	InUse int
+	// Created is the total number of connections ever dialed by the pool.
+	Created int64
+	// Closed is the total number of connections ever closed by the pool.
+	Closed int64
+	// Failed is the total number of dial attempts that returned an error.
+	Failed int64
+	// WaitCount is the total number of Get calls that had to wait for a
+	// connection because the pool was at its maximum capacity.
+	WaitCount int64
+	// WaitDuration is the cumulative time spent waiting across all
+	// WaitCount occurrences.
+	WaitDuration time.Duration
+}
+
+// MetricsCollector lets callers plug their own instrumentation (e.g.
+// Prometheus counters) into a connPool via SetMetricsCollector. Methods are
+// invoked synchronously from pool operations and must not block.
+type MetricsCollector interface {
+	// ConnCreated is called every time the pool successfully dials a new
+	// connection.
+	ConnCreated()
+	// ConnClosed is called every time the pool closes a connection,
+	// whether idle or checked out.
+	ConnClosed()
+	// ConnFailed is called every time a dial attempt fails.
+	ConnFailed()
+	// Wait is called every time Get had to wait for a connection to
+	// become available, reporting how long the wait took.
+	Wait(d time.Duration)
+}
+
+// waitSampler is an opt-in background consumer that turns the wait
+// durations recorded whenever Get blocks into a coarse histogram, bucketed
+// by the boundaries it was started with.
+type waitSampler struct {
+	samples chan time.Duration
+
+	mu        sync.Mutex
+	buckets   []time.Duration
+	histogram []int64
+}
+
+// EnableWaitSampler starts a background goroutine that buckets the wait
+// durations recorded whenever Get blocks because the pool is at capacity.
+// It is opt-in and disabled by default, since most callers only need the
+// aggregate WaitCount/WaitDuration already exposed by Stat(). buckets must
+// be sorted ascending; the returned histogram has one extra bucket counting
+// waits longer than the last boundary.
+func (c *connPool) EnableWaitSampler(buckets []time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sampler != nil {
+		return
+	}
+	c.sampler = &waitSampler{
+		samples:   make(chan time.Duration, 256),
+		buckets:   buckets,
+		histogram: make([]int64, len(buckets)+1),
+	}
+	go c.sampler.run()
+}
+
+// WaitHistogram returns a snapshot of the wait-time histogram recorded
+// since EnableWaitSampler was called, or nil if the sampler was never
+// enabled.
+func (c *connPool) WaitHistogram() []int64 {
+	c.mu.Lock()
+	sampler := c.sampler
+	c.mu.Unlock()
+	if sampler == nil {
+		return nil
+	}
+	return sampler.snapshot()
+}
+
+func (s *waitSampler) record(d time.Duration) {
+	select {
+	case s.samples <- d:
+	default:
+		// The sampler is falling behind; drop the sample rather than
+		// block the caller that is returning from Get.
+	}
+}
+
+func (s *waitSampler) run() {
+	for d := range s.samples {
+		idx := len(s.buckets)
+		for i, b := range s.buckets {
+			if d <= b {
+				idx = i
+				break
+			}
+		}
+		s.mu.Lock()
+		s.histogram[idx]++
+		s.mu.Unlock()
+	}
+}
+
+func (s *waitSampler) snapshot() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]int64, len(s.histogram))
+	copy(out, s.histogram)
+	return out
+}
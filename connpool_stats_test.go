@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2022-2024 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type countingCollector struct {
+	created, closed, failed int
+	waits                   int
+}
+
+func (c *countingCollector) ConnCreated()         { c.created++ }
+func (c *countingCollector) ConnClosed()          { c.closed++ }
+func (c *countingCollector) ConnFailed()          { c.failed++ }
+func (c *countingCollector) Wait(_ time.Duration) { c.waits++ }
+
+func TestConnPool_Stat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 15
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	p, err := newConnPool(serverPort)
+	if err != nil {
+		t.Fatalf("failed to create connection pool: %s", err)
+	}
+	defer p.Close()
+
+	collector := &countingCollector{}
+	p.SetMetricsCollector(collector)
+
+	stat := p.Stat()
+	if stat.Total != 5 || stat.Idle != 5 || stat.InUse != 0 {
+		t.Errorf("unexpected initial stats: %+v", stat)
+	}
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("failed to get connection: %s", err)
+	}
+	defer conn.Close()
+
+	stat = p.Stat()
+	if stat.Idle != 4 || stat.InUse != 1 {
+		t.Errorf("unexpected stats after Get: %+v", stat)
+	}
+
+	p.EnableWaitSampler([]time.Duration{time.Millisecond, time.Second})
+	if histogram := p.WaitHistogram(); len(histogram) != 3 {
+		t.Errorf("expected a 3-bucket histogram, got %d buckets", len(histogram))
+	}
+}
@@ -6,6 +6,7 @@ package mail
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -144,6 +145,70 @@ func TestConnPool_Get(t *testing.T) {
 	p.Close()
 }
 
+func TestConnPool_GetWithTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 13
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	netDialer := net.Dialer{}
+	p, err := NewConnPool(context.Background(), 1, 1, netDialer.DialContext, "tcp",
+		fmt.Sprintf("127.0.0.1:%d", serverPort))
+	if err != nil {
+		t.Fatalf("failed to create connection pool: %s", err)
+	}
+	defer p.Close()
+
+	if _, err = p.Get(); err != nil {
+		t.Fatalf("failed to get the only available connection: %s", err)
+	}
+
+	if _, err = p.GetWithTimeout(time.Millisecond * 100); !errors.Is(err, ErrPoolTimeout) {
+		t.Errorf("expected ErrPoolTimeout, got: %v", err)
+	}
+}
+
+func TestConnPool_GetWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 14
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	netDialer := net.Dialer{}
+	p, err := NewConnPool(context.Background(), 1, 1, netDialer.DialContext, "tcp",
+		fmt.Sprintf("127.0.0.1:%d", serverPort))
+	if err != nil {
+		t.Fatalf("failed to create connection pool: %s", err)
+	}
+	defer p.Close()
+
+	if _, err = p.Get(); err != nil {
+		t.Fatalf("failed to get the only available connection: %s", err)
+	}
+
+	getCtx, getCancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer getCancel()
+	if _, err = p.GetWithContext(getCtx); !errors.Is(err, ErrPoolTimeout) {
+		t.Errorf("expected ErrPoolTimeout, got: %v", err)
+	}
+}
+
 func newConnPool(port int) (Pool, error) {
 	netDialer := net.Dialer{}
 	return NewConnPool(context.Background(), 5, 30, netDialer.DialContext, "tcp",
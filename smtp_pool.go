@@ -0,0 +1,327 @@
+// SPDX-FileCopyrightText: 2022-2024 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"sync"
+	"time"
+)
+
+// maxSessionFailures is the number of consecutive failures a pooled SMTP
+// session tolerates before it is discarded instead of being returned to the
+// pool.
+const maxSessionFailures = 3
+
+// ErrSMTPPoolClosed is returned by SMTPPool methods once the pool has been
+// closed via Close.
+var ErrSMTPPoolClosed = errors.New("mail: SMTP session pool is closed")
+
+// SMTPPool is a pool of live, already EHLO'd (and, where configured,
+// STARTTLS'd and authenticated) smtp.Client sessions. Unlike connPool, which
+// only hands out bare net.Conn connections, the SMTPPool amortizes the cost
+// of the full SMTP handshake across many DialAndSend/Send calls instead of
+// paying for it on every message.
+type SMTPPool struct {
+	mu          sync.Mutex
+	sessions    chan *pooledSession
+	failCounts  map[*smtp.Client]int
+	rebuild     chan struct{}
+	handshake   func(ctx context.Context) (*smtp.Client, error)
+	min, max    int
+	current     int
+	idleTimeout time.Duration
+	closed      bool
+}
+
+// pooledSession wraps an established smtp.Client session together with the
+// time it was last returned to the pool.
+type pooledSession struct {
+	client    *smtp.Client
+	idleSince time.Time
+}
+
+// EnableConnectionPool turns the Client into a pooled sender. Instead of
+// dialing and running the full EHLO/STARTTLS/AUTH handshake on every
+// DialAndSend call, the Client keeps between min and max live SMTP sessions
+// open and hands them out on demand, issuing RSET between messages rather
+// than tearing the session down. idleTimeout bounds how long an idle
+// session is kept before it is health-checked with a NOOP.
+func (c *Client) EnableConnectionPool(min, max int, idleTimeout time.Duration) error {
+	if min < 0 || max <= 0 || min > max {
+		return fmt.Errorf("mail: invalid SMTP pool capacity (min: %d, max: %d)", min, max)
+	}
+
+	pool, err := newSMTPPool(min, max, idleTimeout, c.newPooledSession)
+	if err != nil {
+		return err
+	}
+	c.smtpPool = pool
+	return nil
+}
+
+// newSMTPPool pre-fills a new SMTPPool with min sessions obtained from
+// handshake, never growing it beyond max.
+func newSMTPPool(min, max int, idleTimeout time.Duration,
+	handshake func(ctx context.Context) (*smtp.Client, error)) (*SMTPPool, error) {
+	pool := &SMTPPool{
+		sessions:    make(chan *pooledSession, max),
+		failCounts:  make(map[*smtp.Client]int),
+		rebuild:     make(chan struct{}, max),
+		handshake:   handshake,
+		min:         min,
+		max:         max,
+		idleTimeout: idleTimeout,
+	}
+	for i := 0; i < min; i++ {
+		session, err := pool.dial(context.Background())
+		if err != nil {
+			_ = pool.Close()
+			return nil, fmt.Errorf("mail: failed to pre-fill SMTP session pool: %w", err)
+		}
+		pool.sessions <- session
+		pool.current++
+	}
+	pool.startRebuildWorker()
+	return pool, nil
+}
+
+// startRebuildWorker runs for the lifetime of the pool, redialing a
+// replacement session every time Put discards one after maxSessionFailures
+// consecutive failures, so the pool is proactively topped back up instead
+// of waiting for the next Get to notice it is short.
+func (p *SMTPPool) startRebuildWorker() {
+	go func() {
+		for range p.rebuild {
+			session, err := p.dial(context.Background())
+			if err != nil {
+				continue
+			}
+
+			p.mu.Lock()
+			if p.closed || p.current >= p.max {
+				p.mu.Unlock()
+				_ = session.client.Quit()
+				continue
+			}
+			p.current++
+			p.mu.Unlock()
+
+			select {
+			case p.sessions <- session:
+			default:
+				p.discard(session.client)
+			}
+		}
+	}()
+}
+
+// newPooledSession dials the Client's configured server and performs the
+// full handshake (EHLO, and where configured STARTTLS and AUTH) exactly
+// once for the resulting session. It dials through a copy of the Client, so
+// concurrent dials never share the Client's own smtpClient field.
+func (c *Client) newPooledSession(ctx context.Context) (*smtp.Client, error) {
+	dialer := *c
+	if err := dialer.DialWithContext(ctx); err != nil {
+		return nil, err
+	}
+	return dialer.smtpClient, nil
+}
+
+// dial runs the pool's handshake and wraps the resulting client in a new
+// pooledSession. It does not check the pool's capacity; its callers account
+// for the new session in current.
+func (p *SMTPPool) dial(ctx context.Context) (*pooledSession, error) {
+	sc, err := p.handshake(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledSession{client: sc, idleSince: time.Now()}, nil
+}
+
+// Get returns a healthy, handshake-complete SMTP session from the pool,
+// dialing a new one if the pool has not yet reached its maximum capacity.
+// Sessions that have been idle for longer than idleTimeout are health
+// checked with a NOOP before being handed out; sessions that fail the check
+// are discarded and a replacement is dialed instead.
+func (p *SMTPPool) Get(ctx context.Context) (*smtp.Client, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrSMTPPoolClosed
+		}
+		select {
+		case session := <-p.sessions:
+			p.mu.Unlock()
+			if p.idleTimeout > 0 && time.Since(session.idleSince) > p.idleTimeout {
+				if err := session.client.Noop(); err != nil {
+					p.discard(session.client)
+					continue
+				}
+			}
+			return session.client, nil
+		default:
+		}
+		if p.current >= p.max {
+			p.mu.Unlock()
+			select {
+			case session := <-p.sessions:
+				if p.idleTimeout > 0 && time.Since(session.idleSince) > p.idleTimeout {
+					if err := session.client.Noop(); err != nil {
+						p.discard(session.client)
+						continue
+					}
+				}
+				return session.client, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		p.current++
+		p.mu.Unlock()
+
+		session, err := p.dial(ctx)
+		if err != nil {
+			p.mu.Lock()
+			p.current--
+			p.mu.Unlock()
+			return nil, fmt.Errorf("mail: failed to dial new SMTP session: %w", err)
+		}
+		return session.client, nil
+	}
+}
+
+// Put returns an SMTP session to the pool. If sendErr is non-nil, the
+// session's consecutive failure count is incremented and the session is
+// discarded once it reaches maxSessionFailures; otherwise the failure count
+// is reset. Every session that is not discarded is issued a RSET so it is
+// ready for the next message, and is discarded instead if the RSET fails.
+func (p *SMTPPool) Put(client *smtp.Client, sendErr error) {
+	if sendErr != nil {
+		p.mu.Lock()
+		p.failCounts[client]++
+		failed := p.failCounts[client]
+		p.mu.Unlock()
+
+		if failed >= maxSessionFailures {
+			p.discard(client)
+			p.mu.Lock()
+			if !p.closed {
+				select {
+				case p.rebuild <- struct{}{}:
+				default:
+				}
+			}
+			p.mu.Unlock()
+			return
+		}
+	} else {
+		p.mu.Lock()
+		delete(p.failCounts, client)
+		p.mu.Unlock()
+	}
+
+	if err := client.Reset(); err != nil {
+		p.discard(client)
+		return
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.discard(client)
+		return
+	}
+	p.mu.Unlock()
+
+	session := &pooledSession{client: client, idleSince: time.Now()}
+	select {
+	case p.sessions <- session:
+	default:
+		p.discard(client)
+	}
+}
+
+// discard closes a session's underlying connection and frees its slot in
+// the pool's capacity so a future Get can dial a replacement.
+func (p *SMTPPool) discard(client *smtp.Client) {
+	_ = client.Quit()
+	p.mu.Lock()
+	p.current--
+	delete(p.failCounts, client)
+	p.mu.Unlock()
+}
+
+// borrowSession returns a pooled SMTP session for c if connection pooling
+// has been enabled via EnableConnectionPool, and a release func that must
+// be called with the outcome of the send once the caller is done with the
+// session. DialAndSend uses this to transparently borrow from the pool
+// instead of dialing and handshaking from scratch on every call.
+func (c *Client) borrowSession(ctx context.Context) (*smtp.Client, func(error), error) {
+	if c.smtpPool == nil {
+		return nil, nil, nil
+	}
+	sc, err := c.smtpPool.Get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sc, func(sendErr error) { c.smtpPool.Put(sc, sendErr) }, nil
+}
+
+// DialAndSend sends messages over the Client's configured server. If
+// EnableConnectionPool has been called, it transparently borrows an
+// already-handshaked session from the pool instead of dialing and running
+// EHLO/STARTTLS/AUTH from scratch, and returns the session to the pool
+// (or discards it, per Put's failure tracking) once the send completes.
+//
+// A Client with pooling enabled is safe for concurrent DialAndSend calls:
+// each call sends over its own borrowed session through a per-call copy of
+// the Client, rather than the shared Client itself, so concurrent borrowers
+// never race on a single smtpClient field.
+func (c *Client) DialAndSend(messages ...*Msg) error {
+	ctx := context.Background()
+
+	if c.smtpPool == nil {
+		if err := c.DialWithContext(ctx); err != nil {
+			return fmt.Errorf("mail: failed to dial: %w", err)
+		}
+		defer func() { _ = c.Close() }()
+		return c.Send(messages...)
+	}
+
+	sc, release, err := c.borrowSession(ctx)
+	if err != nil {
+		return fmt.Errorf("mail: failed to borrow pooled SMTP session: %w", err)
+	}
+	sender := *c
+	sender.smtpClient = sc
+	sendErr := sender.Send(messages...)
+	release(sendErr)
+	return sendErr
+}
+
+// Close closes the pool and every idle session it currently holds. Sessions
+// that are checked out at the time Close is called are closed as soon as
+// they are returned via Put.
+func (p *SMTPPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.sessions)
+	close(p.rebuild)
+	p.mu.Unlock()
+
+	for session := range p.sessions {
+		_ = session.client.Quit()
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2022-2024 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestSMTPPool(t *testing.T, serverPort, min, max int) *SMTPPool {
+	t.Helper()
+	handshake := func(ctx context.Context) (*smtp.Client, error) {
+		sc, err := smtp.Dial(fmt.Sprintf("127.0.0.1:%d", serverPort))
+		if err != nil {
+			return nil, err
+		}
+		if err = sc.Hello("test.localhost.localdomain"); err != nil {
+			return nil, err
+		}
+		return sc, nil
+	}
+	pool, err := newSMTPPool(min, max, 0, handshake)
+	if err != nil {
+		t.Fatalf("failed to create SMTP session pool: %s", err)
+	}
+	return pool
+}
+
+func TestNewSMTPPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 20
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	pool := newTestSMTPPool(t, serverPort, 2, 5)
+	defer pool.Close()
+
+	if pool.current != 2 {
+		t.Errorf("expected 2 pre-filled sessions, got %d", pool.current)
+	}
+}
+
+func TestSMTPPool_GetPut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 21
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	pool := newTestSMTPPool(t, serverPort, 1, 2)
+	defer pool.Close()
+
+	session, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get session from pool: %s", err)
+	}
+	pool.Put(session, nil)
+
+	if len(pool.sessions) != 1 {
+		t.Errorf("expected session to be returned to the pool, got %d idle sessions", len(pool.sessions))
+	}
+}
+
+func TestSMTPPool_PutDiscardsAfterMaxFailures(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 22
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	pool := newTestSMTPPool(t, serverPort, 1, 2)
+	defer pool.Close()
+
+	session, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get session from pool: %s", err)
+	}
+
+	sendErr := fmt.Errorf("simulated send failure")
+	for i := 0; i < maxSessionFailures; i++ {
+		if i > 0 {
+			if session, err = pool.Get(context.Background()); err != nil {
+				t.Fatalf("failed to get session from pool: %s", err)
+			}
+		}
+		pool.Put(session, sendErr)
+	}
+
+	if len(pool.sessions) != 0 {
+		t.Errorf("expected session to be discarded after %d failures, got %d idle sessions",
+			maxSessionFailures, len(pool.sessions))
+	}
+}
+
+func newTestPooledMsg(t *testing.T) *Msg {
+	t.Helper()
+	msg := NewMsg()
+	if err := msg.From("sender@example.com"); err != nil {
+		t.Fatalf("failed to set From address: %s", err)
+	}
+	if err := msg.To("recipient@example.com"); err != nil {
+		t.Fatalf("failed to set To address: %s", err)
+	}
+	msg.Subject("pooled send")
+	msg.SetBodyString(TypeTextPlain, "this is a pooled test message")
+	return msg
+}
+
+// TestClient_DialAndSend_Pooled exercises EnableConnectionPool end-to-end
+// through DialAndSend, rather than driving the SMTPPool directly.
+func TestClient_DialAndSend_Pooled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 23
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	client, err := NewClient("127.0.0.1", WithPort(serverPort), WithTLSPolicy(NoTLS))
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	if err = client.EnableConnectionPool(1, 2, 0); err != nil {
+		t.Fatalf("failed to enable connection pool: %s", err)
+	}
+
+	if err = client.DialAndSend(newTestPooledMsg(t)); err != nil {
+		t.Fatalf("failed to send via pooled client: %s", err)
+	}
+}
+
+// TestClient_DialAndSend_PooledConcurrent drives DialAndSend from many
+// goroutines on the same pooled Client at once. It must be run with -race:
+// before DialAndSend stopped mutating the shared Client.smtpClient field,
+// this reliably reported a data race on a concurrent send.
+func TestClient_DialAndSend_PooledConcurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverPort := TestServerPortBase + 24
+	featureSet := "250-AUTH PLAIN\r\n250-8BITMIME\r\n250-DSN\r\n250 SMTPUTF8"
+	go func() {
+		if err := simpleSMTPServer(ctx, featureSet, true, serverPort); err != nil {
+			t.Errorf("failed to start test server: %s", err)
+			return
+		}
+	}()
+	time.Sleep(time.Millisecond * 300)
+
+	client, err := NewClient("127.0.0.1", WithPort(serverPort), WithTLSPolicy(NoTLS))
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	if err = client.EnableConnectionPool(2, 4, 0); err != nil {
+		t.Fatalf("failed to enable connection pool: %s", err)
+	}
+
+	const concurrency = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sendErr := client.DialAndSend(newTestPooledMsg(t)); sendErr != nil {
+				errs <- sendErr
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for sendErr := range errs {
+		t.Errorf("concurrent pooled DialAndSend failed: %s", sendErr)
+	}
+}